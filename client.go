@@ -1,18 +1,26 @@
 package api
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
 	"gopkg.in/h2non/gentleman.v2"
 	"gopkg.in/h2non/gentleman.v2/plugins/timeout"
+	"gopkg.in/h2non/gentleman.v2/plugins/transport"
 )
 
 var (
-	ErrUnauthorized = errors.New(http.StatusText(http.StatusUnauthorized))
-	ErrForbidden    = errors.New(http.StatusText(http.StatusForbidden))
+	ErrBadRequest      = errors.New(http.StatusText(http.StatusBadRequest))
+	ErrUnauthorized    = errors.New(http.StatusText(http.StatusUnauthorized))
+	ErrForbidden       = errors.New(http.StatusText(http.StatusForbidden))
+	ErrNotFound        = errors.New(http.StatusText(http.StatusNotFound))
+	ErrConflict        = errors.New(http.StatusText(http.StatusConflict))
+	ErrTooManyRequests = errors.New(http.StatusText(http.StatusTooManyRequests))
+	ErrInternalServer  = errors.New(http.StatusText(http.StatusInternalServerError))
 )
 
 type apiError struct {
@@ -27,28 +35,185 @@ func (err apiError) Error() string {
 	return err.Message
 }
 
+// APIError is returned for any non-2xx response from the SFTPGo API.
+// Use errors.Is against the Err* sentinels to test for a specific status code.
+type APIError struct {
+	StatusCode int
+	Message    string
+	Err        string
+
+	wrapped error
+}
+
+func (e *APIError) Error() string {
+	if e.wrapped != nil {
+		return e.wrapped.Error()
+	}
+
+	msg := e.Err
+	if msg == `` {
+		msg = e.Message
+	}
+	return fmt.Sprintf(`%d: %s`, e.StatusCode, msg)
+}
+
+func (e *APIError) Unwrap() error {
+	if e.wrapped != nil {
+		return e.wrapped
+	}
+	return sentinelForStatus(e.StatusCode)
+}
+
+func sentinelForStatus(statusCode int) error {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return ErrBadRequest
+	case http.StatusUnauthorized:
+		return ErrUnauthorized
+	case http.StatusForbidden:
+		return ErrForbidden
+	case http.StatusNotFound:
+		return ErrNotFound
+	case http.StatusConflict:
+		return ErrConflict
+	case http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	default:
+		if statusCode >= http.StatusInternalServerError {
+			return ErrInternalServer
+		}
+		return errors.New(http.StatusText(statusCode))
+	}
+}
+
 type Client interface {
 	GetAllUsers() (Users, error)
+	GetAllUsersContext(ctx context.Context) (Users, error)
 	GetUsers(input GetUsersInput) (Users, error)
+	GetUsersContext(ctx context.Context, input GetUsersInput) (Users, error)
+	GetUsersIter(ctx context.Context, input GetUsersInput) (*UserIterator, error)
 	GetUserQuotaScans() (UserQuotaScans, error)
+	GetUserQuotaScansContext(ctx context.Context) (UserQuotaScans, error)
 	StartUserQuotaScan(User User) error
+	StartUserQuotaScanContext(ctx context.Context, User User) error
 	GetActiveConnections() ([]ConnectionStatus, error)
+	GetActiveConnectionsContext(ctx context.Context) ([]ConnectionStatus, error)
+	TerminateActiveConnection(connectionID string) error
+	TerminateActiveConnectionContext(ctx context.Context, connectionID string) error
+
+	AddUser(User User) (User, error)
+	AddUserContext(ctx context.Context, User User) (User, error)
+	GetUser(username string) (User, error)
+	GetUserContext(ctx context.Context, username string) (User, error)
+	UpdateUser(User User) error
+	UpdateUserContext(ctx context.Context, User User) error
+	DeleteUser(username string) error
+	DeleteUserContext(ctx context.Context, username string) error
+
+	AddFolder(Folder Folder) (Folder, error)
+	AddFolderContext(ctx context.Context, Folder Folder) (Folder, error)
+	GetFolders() ([]Folder, error)
+	GetFoldersContext(ctx context.Context) ([]Folder, error)
+	GetFolder(name string) (Folder, error)
+	GetFolderContext(ctx context.Context, name string) (Folder, error)
+	UpdateFolder(Folder Folder) error
+	UpdateFolderContext(ctx context.Context, Folder Folder) error
+	DeleteFolder(name string) error
+	DeleteFolderContext(ctx context.Context, name string) error
+
+	DumpData() (Backup, error)
+	DumpDataContext(ctx context.Context) (Backup, error)
+	Loaddata(backup Backup, scanQuota int, mode int) error
+	LoaddataContext(ctx context.Context, backup Backup, scanQuota int, mode int) error
 }
 
 type client struct {
 	cli *gentleman.Client
 }
 
+type clientOptions struct {
+	baseURL     string
+	credentials Credentials
+	httpClient  *http.Client
+	timeout     time.Duration
+}
+
+type ClientOption func(*clientOptions)
+
+// WithCredentials sets the authentication strategy used for every request.
+// Defaults to an empty BasicCredentials if not set
+func WithCredentials(credentials Credentials) ClientOption {
+	return func(o *clientOptions) {
+		o.credentials = credentials
+	}
+}
+
+// WithBaseURL sets the SFTPGo REST API base URL
+func WithBaseURL(URL string) ClientOption {
+	return func(o *clientOptions) {
+		o.baseURL = URL
+	}
+}
+
+// WithTimeout overrides the default request timeout
+func WithTimeout(d time.Duration) ClientOption {
+	return func(o *clientOptions) {
+		o.timeout = d
+	}
+}
+
+// WithHTTPClient overrides the HTTP transport used to make requests.
+// Only Timeout and Transport are honored: Timeout (if set) overrides
+// WithTimeout, and Transport (if set) is used for outgoing requests.
+// Jar and CheckRedirect are not supported by the underlying HTTP client
+// and are ignored
+func WithHTTPClient(httpClient *http.Client) ClientOption {
+	return func(o *clientOptions) {
+		o.httpClient = httpClient
+	}
+}
+
+// NewClient creates a Client authenticating with HTTP Basic credentials.
+// Use NewClientWithOptions for API key or two-factor authentication
 func NewClient(URL, Username, Password string) Client {
+	return NewClientWithOptions(
+		WithBaseURL(URL),
+		WithCredentials(BasicCredentials{Username: Username, Password: Password}),
+	)
+}
+
+// NewClientWithOptions creates a Client using the given options
+func NewClientWithOptions(opts ...ClientOption) Client {
+	options := clientOptions{
+		timeout: 15 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	if options.credentials == nil {
+		options.credentials = BasicCredentials{}
+	}
+	if options.httpClient != nil && options.httpClient.Timeout > 0 {
+		options.timeout = options.httpClient.Timeout
+	}
+
+	var rt http.RoundTripper
+	if options.httpClient != nil {
+		rt = options.httpClient.Transport
+	}
+
 	cli := gentleman.New()
-	cli.URL(URL)
-	cli.Use(NewTokenPlugin(URL, Username, Password))
+	cli.URL(options.baseURL)
+	cli.Use(options.credentials.NewPlugin(options.baseURL, rt, options.timeout))
 	cli.Use(timeout.All(timeout.Timeouts{
-		Request:   15 * time.Second,
+		Request:   options.timeout,
 		TLS:       5 * time.Second,
 		Dial:      5 * time.Second,
-		KeepAlive: 15 * time.Second,
+		KeepAlive: options.timeout,
 	}))
+	if rt != nil {
+		cli.Use(transport.Set(rt))
+	}
 
 	return &client{cli: cli}
 }
@@ -60,6 +225,10 @@ type GetUsersInput struct {
 }
 
 func (c *client) GetAllUsers() (Users, error) {
+	return c.GetAllUsersContext(context.Background())
+}
+
+func (c *client) GetAllUsersContext(ctx context.Context) (Users, error) {
 	Input := GetUsersInput{
 		Offset: 0,
 		Limit:  500,
@@ -67,7 +236,7 @@ func (c *client) GetAllUsers() (Users, error) {
 
 	var all Users
 	for {
-		page, err := c.GetUsers(Input)
+		page, err := c.GetUsersContext(ctx, Input)
 		if err != nil {
 			return nil, err
 		}
@@ -82,7 +251,12 @@ func (c *client) GetAllUsers() (Users, error) {
 }
 
 func (c *client) GetUsers(input GetUsersInput) (Users, error) {
+	return c.GetUsersContext(context.Background(), input)
+}
+
+func (c *client) GetUsersContext(ctx context.Context, input GetUsersInput) (Users, error) {
 	req := c.cli.Request()
+	req.SetContext(ctx)
 	req.Path(`/api/v2/users`)
 	if input.Offset > 0 {
 		req.SetQuery(`offset`, strconv.Itoa(input.Offset))
@@ -101,8 +275,65 @@ func (c *client) GetUsers(input GetUsersInput) (Users, error) {
 	return users, nil
 }
 
+// UserIterator yields users page-by-page, fetching each page lazily so that
+// instances with very large user counts don't need to be loaded into memory
+// all at once
+type UserIterator struct {
+	ctx    context.Context
+	client *client
+	input  GetUsersInput
+	page   Users
+	index  int
+	done   bool
+}
+
+// GetUsersIter returns a UserIterator that honors ctx.Done() between pages
+func (c *client) GetUsersIter(ctx context.Context, input GetUsersInput) (*UserIterator, error) {
+	if input.Limit <= 0 {
+		input.Limit = 500
+	}
+	return &UserIterator{ctx: ctx, client: c, input: input}, nil
+}
+
+// Next returns the next user, or false once the iterator is exhausted
+func (it *UserIterator) Next() (User, bool, error) {
+	for it.index >= len(it.page) {
+		if it.done {
+			return User{}, false, nil
+		}
+		select {
+		case <-it.ctx.Done():
+			return User{}, false, it.ctx.Err()
+		default:
+		}
+
+		page, err := it.client.GetUsersContext(it.ctx, it.input)
+		if err != nil {
+			return User{}, false, err
+		}
+		it.page = page
+		it.index = 0
+		it.input.Offset += it.input.Limit
+		if len(page) < it.input.Limit {
+			it.done = true
+		}
+		if len(page) == 0 {
+			return User{}, false, nil
+		}
+	}
+
+	user := it.page[it.index]
+	it.index++
+	return user, true, nil
+}
+
 func (c *client) GetUserQuotaScans() (UserQuotaScans, error) {
+	return c.GetUserQuotaScansContext(context.Background())
+}
+
+func (c *client) GetUserQuotaScansContext(ctx context.Context) (UserQuotaScans, error) {
 	req := c.cli.Request()
+	req.SetContext(ctx)
 	req.Path(`/api/v2/quota-scans`)
 	var scans UserQuotaScans
 	if err := doJSON(req, &scans); err != nil {
@@ -112,7 +343,12 @@ func (c *client) GetUserQuotaScans() (UserQuotaScans, error) {
 }
 
 func (c *client) GetActiveConnections() ([]ConnectionStatus, error) {
+	return c.GetActiveConnectionsContext(context.Background())
+}
+
+func (c *client) GetActiveConnectionsContext(ctx context.Context) ([]ConnectionStatus, error) {
 	req := c.cli.Request()
+	req.SetContext(ctx)
 	req.Path(`/api/v2/connections`)
 	var connections []ConnectionStatus
 	if err := doJSON(req, &connections); err != nil {
@@ -122,7 +358,12 @@ func (c *client) GetActiveConnections() ([]ConnectionStatus, error) {
 }
 
 func (c *client) StartUserQuotaScan(User User) error {
+	return c.StartUserQuotaScanContext(context.Background(), User)
+}
+
+func (c *client) StartUserQuotaScanContext(ctx context.Context, User User) error {
 	req := c.cli.Request()
+	req.SetContext(ctx)
 	req.Method(`POST`)
 	req.Path(`/api/v2/quota-scans`)
 	req.JSON(&User)
@@ -137,6 +378,232 @@ func (c *client) StartUserQuotaScan(User User) error {
 	return parseErrorResponse(resp)
 }
 
+func (c *client) TerminateActiveConnection(connectionID string) error {
+	return c.TerminateActiveConnectionContext(context.Background(), connectionID)
+}
+
+func (c *client) TerminateActiveConnectionContext(ctx context.Context, connectionID string) error {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Method(`DELETE`)
+	req.Path(fmt.Sprintf(`/api/v2/connections/%s`, connectionID))
+	resp, err := req.Do()
+	if err != nil {
+		return err
+	}
+	if resp.Ok {
+		return nil
+	}
+
+	return parseErrorResponse(resp)
+}
+
+func (c *client) AddUser(User User) (User, error) {
+	return c.AddUserContext(context.Background(), User)
+}
+
+func (c *client) AddUserContext(ctx context.Context, User User) (User, error) {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Method(`POST`)
+	req.Path(`/api/v2/users`)
+	req.JSON(&User)
+
+	var added User
+	if err := doJSON(req, &added); err != nil {
+		return User, err
+	}
+	return added, nil
+}
+
+func (c *client) GetUser(username string) (User, error) {
+	return c.GetUserContext(context.Background(), username)
+}
+
+func (c *client) GetUserContext(ctx context.Context, username string) (User, error) {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Path(fmt.Sprintf(`/api/v2/users/%s`, username))
+
+	var user User
+	if err := doJSON(req, &user); err != nil {
+		return user, err
+	}
+	return user, nil
+}
+
+func (c *client) UpdateUser(User User) error {
+	return c.UpdateUserContext(context.Background(), User)
+}
+
+func (c *client) UpdateUserContext(ctx context.Context, User User) error {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Method(`PUT`)
+	req.Path(fmt.Sprintf(`/api/v2/users/%s`, User.Username))
+	req.JSON(&User)
+	resp, err := req.Do()
+	if err != nil {
+		return err
+	}
+	if resp.Ok {
+		return nil
+	}
+
+	return parseErrorResponse(resp)
+}
+
+func (c *client) DeleteUser(username string) error {
+	return c.DeleteUserContext(context.Background(), username)
+}
+
+func (c *client) DeleteUserContext(ctx context.Context, username string) error {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Method(`DELETE`)
+	req.Path(fmt.Sprintf(`/api/v2/users/%s`, username))
+	resp, err := req.Do()
+	if err != nil {
+		return err
+	}
+	if resp.Ok {
+		return nil
+	}
+
+	return parseErrorResponse(resp)
+}
+
+func (c *client) AddFolder(Folder Folder) (Folder, error) {
+	return c.AddFolderContext(context.Background(), Folder)
+}
+
+func (c *client) AddFolderContext(ctx context.Context, Folder Folder) (Folder, error) {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Method(`POST`)
+	req.Path(`/api/v2/folders`)
+	req.JSON(&Folder)
+
+	var added Folder
+	if err := doJSON(req, &added); err != nil {
+		return Folder, err
+	}
+	return added, nil
+}
+
+func (c *client) GetFolders() ([]Folder, error) {
+	return c.GetFoldersContext(context.Background())
+}
+
+func (c *client) GetFoldersContext(ctx context.Context) ([]Folder, error) {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Path(`/api/v2/folders`)
+
+	var folders []Folder
+	if err := doJSON(req, &folders); err != nil {
+		return nil, err
+	}
+	return folders, nil
+}
+
+func (c *client) GetFolder(name string) (Folder, error) {
+	return c.GetFolderContext(context.Background(), name)
+}
+
+func (c *client) GetFolderContext(ctx context.Context, name string) (Folder, error) {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Path(fmt.Sprintf(`/api/v2/folders/%s`, name))
+
+	var folder Folder
+	if err := doJSON(req, &folder); err != nil {
+		return folder, err
+	}
+	return folder, nil
+}
+
+func (c *client) UpdateFolder(Folder Folder) error {
+	return c.UpdateFolderContext(context.Background(), Folder)
+}
+
+func (c *client) UpdateFolderContext(ctx context.Context, Folder Folder) error {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Method(`PUT`)
+	req.Path(fmt.Sprintf(`/api/v2/folders/%s`, Folder.Name))
+	req.JSON(&Folder)
+	resp, err := req.Do()
+	if err != nil {
+		return err
+	}
+	if resp.Ok {
+		return nil
+	}
+
+	return parseErrorResponse(resp)
+}
+
+func (c *client) DeleteFolder(name string) error {
+	return c.DeleteFolderContext(context.Background(), name)
+}
+
+func (c *client) DeleteFolderContext(ctx context.Context, name string) error {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Method(`DELETE`)
+	req.Path(fmt.Sprintf(`/api/v2/folders/%s`, name))
+	resp, err := req.Do()
+	if err != nil {
+		return err
+	}
+	if resp.Ok {
+		return nil
+	}
+
+	return parseErrorResponse(resp)
+}
+
+func (c *client) DumpData() (Backup, error) {
+	return c.DumpDataContext(context.Background())
+}
+
+func (c *client) DumpDataContext(ctx context.Context) (Backup, error) {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Path(`/api/v2/dumpdata`)
+	req.SetQuery(`output-data`, `1`)
+
+	var backup Backup
+	if err := doJSON(req, &backup); err != nil {
+		return backup, err
+	}
+	return backup, nil
+}
+
+func (c *client) Loaddata(backup Backup, scanQuota int, mode int) error {
+	return c.LoaddataContext(context.Background(), backup, scanQuota, mode)
+}
+
+func (c *client) LoaddataContext(ctx context.Context, backup Backup, scanQuota int, mode int) error {
+	req := c.cli.Request()
+	req.SetContext(ctx)
+	req.Method(`POST`)
+	req.Path(`/api/v2/loaddata`)
+	req.SetQuery(`scan-quota`, strconv.Itoa(scanQuota))
+	req.SetQuery(`mode`, strconv.Itoa(mode))
+	req.JSON(&backup)
+	resp, err := req.Do()
+	if err != nil {
+		return err
+	}
+	if resp.Ok {
+		return nil
+	}
+
+	return parseErrorResponse(resp)
+}
+
 func doJSON(req *gentleman.Request, dest interface{}) error {
 	resp, err := req.Do()
 	if err != nil {
@@ -151,17 +618,23 @@ func doJSON(req *gentleman.Request, dest interface{}) error {
 }
 
 func parseErrorResponse(resp *gentleman.Response) error {
-	switch resp.StatusCode {
-	case http.StatusUnauthorized:
-		return ErrUnauthorized
-	case http.StatusForbidden:
-		return ErrForbidden
-		// TODO: other error codes
-	default:
-		var apiErr apiError
-		if err := resp.JSON(&apiErr); err != nil {
-			return err
-		}
-		return &apiErr
+	// the error body is best-effort: some status codes (e.g. 401/403 from a
+	// reverse proxy in front of SFTPGo) may not carry a JSON payload at all
+	var apiErr apiError
+	_ = resp.JSON(&apiErr)
+
+	msg := apiErr.Err
+	if msg == `` {
+		msg = apiErr.Message
+	}
+	if msg == `` {
+		msg = http.StatusText(resp.StatusCode)
+	}
+
+	return &APIError{
+		StatusCode: resp.StatusCode,
+		Message:    apiErr.Message,
+		Err:        apiErr.Err,
+		wrapped:    fmt.Errorf(`%w: %s`, sentinelForStatus(resp.StatusCode), msg),
 	}
 }
@@ -1,6 +1,9 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -28,5 +31,78 @@ func TestClient_TerminateActiveConnection(t *testing.T) {
 	assert.Nil(t, err)
 
 	err = client.TerminateActiveConnection(`SFTP_002`)
-	assert.Equal(t, ErrUnauthorized, err)
+	assert.True(t, errors.Is(err, ErrUnauthorized))
+}
+
+func TestParseErrorResponse(t *testing.T) {
+	tests := []struct {
+		StatusCode int
+		Sentinel   error
+	}{
+		{http.StatusBadRequest, ErrBadRequest},
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrForbidden},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusConflict, ErrConflict},
+		{http.StatusTooManyRequests, ErrTooManyRequests},
+		{http.StatusInternalServerError, ErrInternalServer},
+		{http.StatusServiceUnavailable, ErrInternalServer},
+	}
+
+	for _, test := range tests {
+		t.Run(http.StatusText(test.StatusCode), func(t *testing.T) {
+			defer mock.Disable()
+
+			mock.New(`http://localhost`).
+				Get(`/api/v2/connections`).
+				Reply(test.StatusCode).
+				JSON(apiError{Message: `boom`})
+
+			cli := gentleman.New()
+			cli.URL(`http://localhost`)
+			cli.Use(mock.Plugin)
+
+			client := client{cli: cli}
+			_, err := client.GetActiveConnections()
+
+			var apiErr *APIError
+			assert.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, test.StatusCode, apiErr.StatusCode)
+			assert.True(t, errors.Is(err, test.Sentinel))
+		})
+	}
+}
+
+func TestClient_GetUsersIter(t *testing.T) {
+	defer mock.Disable()
+
+	mock.New(`http://localhost`).
+		Get(`/api/v2/users`).
+		Reply(200).
+		JSON(Users{{Username: `user1`}, {Username: `user2`}})
+
+	mock.New(`http://localhost`).
+		Get(`/api/v2/users`).
+		Reply(200).
+		JSON(Users{})
+
+	cli := gentleman.New()
+	cli.URL(`http://localhost`)
+	cli.Use(mock.Plugin)
+
+	client := client{cli: cli}
+	iter, err := client.GetUsersIter(context.Background(), GetUsersInput{Limit: 2})
+	assert.Nil(t, err)
+
+	var usernames []string
+	for {
+		user, ok, err := iter.Next()
+		assert.Nil(t, err)
+		if !ok {
+			break
+		}
+		usernames = append(usernames, user.Username)
+	}
+
+	assert.Equal(t, []string{`user1`, `user2`}, usernames)
 }
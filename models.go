@@ -17,12 +17,12 @@ const (
 
 // Filesystem defines cloud storage filesystem details
 type Filesystem struct {
-	Provider FilesystemProvider `json:"provider"`
-	S3Config S3FsConfig         `json:"s3config,omitempty"`
-	// TODO: GCSConfig    vfs.GCSFsConfig    `json:"gcsconfig,omitempty"`
-	// TODO: AzBlobConfig vfs.AzBlobFsConfig `json:"azblobconfig,omitempty"`
-	// TODO: CryptConfig  vfs.CryptFsConfig  `json:"cryptconfig,omitempty"`
-	// TODO: SFTPConfig   vfs.SFTPFsConfig   `json:"sftpconfig,omitempty"`
+	Provider     FilesystemProvider `json:"provider"`
+	S3Config     S3FsConfig         `json:"s3config,omitempty"`
+	GCSConfig    GCSFsConfig        `json:"gcsconfig,omitempty"`
+	AzBlobConfig AzBlobFsConfig     `json:"azblobconfig,omitempty"`
+	CryptConfig  CryptFsConfig      `json:"cryptconfig,omitempty"`
+	SFTPConfig   SFTPFsConfig       `json:"sftpconfig,omitempty"`
 }
 
 // ExtensionsFilter defines filters based on file extensions.
@@ -90,6 +90,70 @@ type UserFilters struct {
 	FilePatterns []PatternsFilter `json:"file_patterns,omitempty"`
 	// max size allowed for a single upload, 0 means unlimited
 	MaxUploadFileSize int64 `json:"max_upload_file_size,omitempty"`
+	// these WebClient restrictions are enabled.
+	// If null or empty no restriction is applied
+	WebClient []string `json:"web_client,omitempty"`
+	// TLSUsername defines the attribute to use as username for TLS certificate
+	// authentication
+	TLSUsername string `json:"tls_username,omitempty"`
+	// UserType hints the authentication provider the user comes from, for
+	// example for users imported from an LDAP server or the OS
+	UserType string `json:"user_type,omitempty"`
+	// BandwidthLimits defines upload/download bandwidth limits based on the
+	// source IP/Mask of the client
+	BandwidthLimits []BandwidthLimit `json:"bandwidth_limits,omitempty"`
+	// Hooks defines overrides for the global hooks
+	Hooks HooksFilter `json:"hooks,omitempty"`
+}
+
+// WebClient restriction options, see UserFilters.WebClient
+const (
+	// WebClientPubKeyChangeDisabled disables changing public keys from the WebClient UI
+	WebClientPubKeyChangeDisabled = "publickey-change-disabled"
+	// WebClientWriteDisabled disables uploading, overwriting and deleting files from the WebClient UI
+	WebClientWriteDisabled = "write-disabled"
+	// WebClientMFADisabled disables two-factor authentication from the WebClient UI
+	WebClientMFADisabled = "mfa-disabled"
+	// WebClientPasswordChangeDisabled disables changing the password from the WebClient UI
+	WebClientPasswordChangeDisabled = "password-change-disabled"
+	// WebClientAPIKeyAuthChangeDisabled disables changing API key auth from the WebClient UI
+	WebClientAPIKeyAuthChangeDisabled = "api-key-auth-change-disabled"
+	// WebClientInfoChangeDisabled disables changing personal info from the WebClient UI
+	WebClientInfoChangeDisabled = "info-change-disabled"
+	// WebClientSharesDisabled disables shares from the WebClient UI
+	WebClientSharesDisabled = "shares-disabled"
+	// WebClientPasswordResetDisabled disables the forgot password function from the WebClient UI
+	WebClientPasswordResetDisabled = "password-reset-disabled"
+)
+
+// UserType hints, see UserFilters.UserType
+const (
+	// UserTypeLDAP marks a user imported from an LDAP server
+	UserTypeLDAP = "LDAPUser"
+	// UserTypeOS marks a user imported from the OS
+	UserTypeOS = "OSUser"
+)
+
+// BandwidthLimit defines upload/download bandwidth limits for specific
+// source networks
+type BandwidthLimit struct {
+	// Sources in CIDR notation that apply to this limit, for example
+	// "192.0.2.0/24" or "2001:db8::/32"
+	Sources []string `json:"sources"`
+	// Maximum upload bandwidth as KB/s, 0 means unlimited
+	UploadBandwidth int64 `json:"upload_bandwidth,omitempty"`
+	// Maximum download bandwidth as KB/s, 0 means unlimited
+	DownloadBandwidth int64 `json:"download_bandwidth,omitempty"`
+}
+
+// HooksFilter defines overrides for the global hooks
+type HooksFilter struct {
+	// ExternalAuthDisabled disables the external auth hook for this user
+	ExternalAuthDisabled bool `json:"external_auth_disabled,omitempty"`
+	// PreLoginDisabled disables the pre-login hook for this user
+	PreLoginDisabled bool `json:"pre_login_disabled,omitempty"`
+	// CheckPasswordDisabled disables the check password hook for this user
+	CheckPasswordDisabled bool `json:"check_password_disabled,omitempty"`
 }
 
 type Users []User
@@ -115,7 +179,7 @@ type User struct {
 	HomeDir string `json:"home_dir"`
 	// Mapping between virtual paths and filesystem paths outside the home directory.
 	// Supported for local filesystem only
-	// TODO: VirtualFolders []vfs.VirtualFolder `json:"virtual_folders,omitempty"`
+	VirtualFolders []VirtualFolder `json:"virtual_folders,omitempty"`
 	// If sftpgo runs as root system user then the created files and directories will be assigned to this system UID
 	UID int `json:"uid"`
 	// If sftpgo runs as root system user then the created files and directories will be assigned to this system GID
@@ -175,6 +239,112 @@ type S3FsConfig struct {
 	UploadConcurrency int `json:"upload_concurrency,omitempty"`
 }
 
+// GCSFsConfig defines the configuration for Google Cloud Storage based filesystem
+type GCSFsConfig struct {
+	Bucket string `json:"bucket,omitempty"`
+	// KeyPrefix is similar to a chroot directory for local filesystem.
+	// If specified then the SFTP user will only see objects that starts
+	// with this prefix and so you can restrict access to a specific
+	// folder. The prefix, if not empty, must not start with "/" and must
+	// end with "/".
+	// If empty the whole bucket contents will be available
+	KeyPrefix string `json:"key_prefix,omitempty"`
+	// Automatic credentials are used if no credentials file is given
+	CredentialFile string `json:"credential_file,omitempty"`
+	Credentials    Secret `json:"credentials,omitempty"`
+	StorageClass   string `json:"storage_class,omitempty"`
+}
+
+// AzBlobFsConfig defines the configuration for Azure Blob Storage based filesystem
+type AzBlobFsConfig struct {
+	Container string `json:"container,omitempty"`
+	// Storage Account Name, leave blank to use SAS URL
+	AccountName string `json:"account_name,omitempty"`
+	// Storage Account Key leave blank to use SAS URL
+	AccountKey Secret `json:"account_key,omitempty"`
+	// Optional endpoint. Default is "blob.core.windows.net".
+	// If you use the emulator the endpoint must include the protocol,
+	// for example "http://127.0.0.1:10000"
+	Endpoint string `json:"endpoint,omitempty"`
+	// Shared access signature URL, leave blank if using account/key
+	SASURL string `json:"sas_url,omitempty"`
+	// KeyPrefix is similar to a chroot directory for local filesystem.
+	// If specified then the SFTP user will only see objects that starts
+	// with this prefix and so you can restrict access to a specific
+	// folder. The prefix, if not empty, must not start with "/" and must
+	// end with "/".
+	// If empty the whole container contents will be available
+	KeyPrefix string `json:"key_prefix,omitempty"`
+	// The buffer size (in MB) to use for multipart uploads.
+	// If this value is set to zero, the default value (1MB) will be used.
+	UploadPartSize int64 `json:"upload_part_size,omitempty"`
+	// How many parts are uploaded in parallel
+	UploadConcurrency int `json:"upload_concurrency,omitempty"`
+	// Set to true if you use an Azure emulator such as Azurite
+	UseEmulator bool `json:"use_emulator,omitempty"`
+	// Blob Access Tier
+	AccessTier string `json:"access_tier,omitempty"`
+}
+
+// CryptFsConfig defines the configuration for local encrypted filesystem
+type CryptFsConfig struct {
+	Passphrase Secret `json:"passphrase,omitempty"`
+}
+
+// SFTPFsConfig defines the configuration for SFTP based filesystem
+type SFTPFsConfig struct {
+	Endpoint string `json:"endpoint,omitempty"`
+	Username string `json:"username,omitempty"`
+	Password Secret `json:"password,omitempty"`
+	// PrivateKey used for public key authentication
+	PrivateKey Secret `json:"private_key,omitempty"`
+	// Fingerprints of the allowed host keys, if empty any host key is allowed
+	Fingerprints []string `json:"fingerprints,omitempty"`
+	// Prefix is similar to a chroot directory for a local filesystem.
+	// If specified then the SFTP user will only see objects inside this
+	// directory and so you can restrict access to a specific directory.
+	// The specified directory must exist on the remote endpoint
+	Prefix string `json:"prefix,omitempty"`
+	// DisableCouncurrentReads disables concurrent reads, that are enabled by default.
+	// Concurrent reads are safe to use and disabling them will degrade performance.
+	// Disable concurrent reads if the remote SFTP server doesn't support opening
+	// the same file for reading concurrently
+	DisableCouncurrentReads bool `json:"disable_concurrent_reads,omitempty"`
+	// BufferSize (in MB) to enable data transfers using multiple concurrent
+	// reads/writes. This improves transfer performance but increases memory usage.
+	// 0 means disabled
+	BufferSize int64 `json:"buffer_size,omitempty"`
+}
+
+// VirtualFolder defines a mapping between a virtual SFTPGo path and a
+// filesystem path outside the user home directory.
+// The specified paths must be absolute and the virtual path cannot be "/",
+// it must be a sub directory. The parent directory for the specified virtual
+// path must exist. If the target directory is not a subdirectory of the
+// user home directory, ensure the user has the rights to create and
+// access this directory
+type VirtualFolder struct {
+	// Path is the SFTPGo Virtual Path
+	Path string `json:"virtual_path"`
+	// MappedPath is the filesystem path outside the user home directory.
+	// Must be unique across all users and folders
+	MappedPath string `json:"mapped_path"`
+	// Description of the folder
+	Description string `json:"description,omitempty"`
+	// Used quota as bytes
+	UsedQuotaSize int64 `json:"used_quota_size,omitempty"`
+	// Used quota as number of files
+	UsedQuotaFiles int `json:"used_quota_files,omitempty"`
+	// Last quota update as unix timestamp in milliseconds
+	LastQuotaUpdate int64 `json:"last_quota_update,omitempty"`
+	// Filesystem configuration details
+	FsConfig Filesystem `json:"filesystem,omitempty"`
+	// Maximum size allowed as bytes. 0 means unlimited
+	QuotaSize int64 `json:"quota_size,omitempty"`
+	// Maximum number of files allowed. 0 means unlimited
+	QuotaFiles int `json:"quota_files,omitempty"`
+}
+
 // SecretStatus defines the statuses of a Secret object
 type SecretStatus = string
 
@@ -256,3 +426,45 @@ func (users Users) Filter(f UserFilterFunc) Users {
 	}
 	return Results
 }
+
+// FilterByWebClientOption returns the users that have the given WebClient
+// restriction enabled, see the WebClient* constants
+func (users Users) FilterByWebClientOption(opt string) Users {
+	return users.Filter(func(user User) bool {
+		for _, o := range user.Filters.WebClient {
+			if o == opt {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Folder defines a virtual folder that can be mapped into one or more users'
+// home directories
+type Folder struct {
+	// Name is the unique identifier for the folder
+	Name string `json:"name"`
+	// MappedPath is the filesystem path outside any user home directory
+	MappedPath string `json:"mapped_path"`
+	// Description of the folder
+	Description string `json:"description,omitempty"`
+	// Used quota as bytes
+	UsedQuotaSize int64 `json:"used_quota_size,omitempty"`
+	// Used quota as number of files
+	UsedQuotaFiles int `json:"used_quota_files,omitempty"`
+	// Last quota update as unix timestamp in milliseconds
+	LastQuotaUpdate int64 `json:"last_quota_update,omitempty"`
+	// Filesystem configuration details
+	FsConfig Filesystem `json:"filesystem,omitempty"`
+	// Usernames of the users that have this folder mapped into their home directory
+	Users []string `json:"users,omitempty"`
+}
+
+// Backup is the full dump of a SFTPGo instance data, as returned by the
+// dumpdata endpoint and accepted by the loaddata endpoint
+type Backup struct {
+	Users   Users    `json:"users"`
+	Folders []Folder `json:"folders"`
+	Version int      `json:"version"`
+}
@@ -18,3 +18,14 @@ func TestUsers_Filter(t *testing.T) {
 	assert.Len(t, filtered, 1)
 	assert.Equal(t, int64(2), filtered[0].ID)
 }
+
+func TestUsers_FilterByWebClientOption(t *testing.T) {
+	var users Users
+	users = append(users, User{ID: 1, Filters: UserFilters{WebClient: []string{WebClientWriteDisabled}}})
+	users = append(users, User{ID: 2, Filters: UserFilters{WebClient: []string{WebClientMFADisabled}}})
+
+	filtered := users.FilterByWebClientOption(WebClientWriteDisabled)
+
+	assert.Len(t, filtered, 1)
+	assert.Equal(t, int64(1), filtered[0].ID)
+}
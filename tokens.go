@@ -1,28 +1,87 @@
 package api
 
 import (
+	"net/http"
+	"sync"
 	"time"
 
+	"github.com/pquerna/otp/totp"
 	"gopkg.in/h2non/gentleman.v2"
 	"gopkg.in/h2non/gentleman.v2/context"
 	"gopkg.in/h2non/gentleman.v2/plugin"
 	"gopkg.in/h2non/gentleman.v2/plugins/auth"
 	"gopkg.in/h2non/gentleman.v2/plugins/timeout"
+	"gopkg.in/h2non/gentleman.v2/plugins/transport"
 )
 
+// Credentials defines an authentication strategy for the SFTPGo API
+type Credentials interface {
+	// NewPlugin returns the gentleman plugin that authenticates requests made
+	// against the given base URL. rt and reqTimeout, if set, are applied to
+	// any auxiliary requests needed to authenticate (e.g. a token exchange)
+	// so that a custom transport/timeout also covers auth requests
+	NewPlugin(URL string, rt http.RoundTripper, reqTimeout time.Duration) plugin.Plugin
+}
+
+// BasicCredentials authenticates by exchanging a username and password for a
+// bearer token against /api/v2/token, reusing it until it expires
+type BasicCredentials struct {
+	Username string
+	Password string
+}
+
+func (c BasicCredentials) NewPlugin(URL string, rt http.RoundTripper, reqTimeout time.Duration) plugin.Plugin {
+	provider := NewTokenProvider(newTokenClient(URL, rt, reqTimeout), c.Username, c.Password)
+	return bearerPlugin(provider)
+}
+
+// APIKeyCredentials authenticates by sending a pre-issued API key on every
+// request. No token exchange is performed
+type APIKeyCredentials struct {
+	Key string
+}
+
+func (c APIKeyCredentials) NewPlugin(URL string, rt http.RoundTripper, reqTimeout time.Duration) plugin.Plugin {
+	return plugin.NewRequestPlugin(func(ctx *context.Context, h context.Handler) {
+		ctx.Request.Header.Set(`X-SFTPGO-API-KEY`, c.Key)
+		h.Next(ctx)
+	})
+}
+
+// TOTPCredentials authenticates like BasicCredentials but also sends a
+// time-based one time passcode, for accounts with two-factor authentication
+// enabled
+type TOTPCredentials struct {
+	Username   string
+	Password   string
+	TOTPSecret string
+}
+
+func (c TOTPCredentials) NewPlugin(URL string, rt http.RoundTripper, reqTimeout time.Duration) plugin.Plugin {
+	provider := NewTOTPTokenProvider(newTokenClient(URL, rt, reqTimeout), c.Username, c.Password, c.TOTPSecret)
+	return bearerPlugin(provider)
+}
+
 type TokenProvider interface {
 	Token() (string, error)
 }
 
 type tokenProvider struct {
-	Value    string    `json:"access_token"`
-	Expires  time.Time `json:"expires_at"`
-	Client   *gentleman.Client
-	Username string
-	Password string
+	Value   string    `json:"access_token"`
+	Expires time.Time `json:"expires_at"`
+
+	Client     *gentleman.Client
+	Username   string
+	Password   string
+	TOTPSecret string
+
+	mu sync.Mutex
 }
 
 func (t *tokenProvider) Token() (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
 	if t.Expires.After(time.Now().Add(time.Second * 10)) {
 		return t.Value, nil
 	}
@@ -30,6 +89,14 @@ func (t *tokenProvider) Token() (string, error) {
 	req := t.Client.Request()
 	req.Path(`/api/v2/token`)
 	req.Use(auth.Basic(t.Username, t.Password))
+	if t.TOTPSecret != `` {
+		passcode, err := totp.GenerateCode(t.TOTPSecret, time.Now())
+		if err != nil {
+			return ``, err
+		}
+		req.SetHeader(`X-SFTPGO-OTP`, passcode)
+	}
+
 	resp, err := req.Do()
 	if err != nil {
 		return ``, err
@@ -52,18 +119,39 @@ func NewTokenProvider(cli *gentleman.Client, Username, Password string) TokenPro
 	}
 }
 
-func NewTokenPlugin(URL, Username, Password string) plugin.Plugin {
+func NewTOTPTokenProvider(cli *gentleman.Client, Username, Password, TOTPSecret string) TokenProvider {
+	return &tokenProvider{
+		Client:     cli,
+		Username:   Username,
+		Password:   Password,
+		TOTPSecret: TOTPSecret,
+	}
+}
+
+// newTokenClient builds the gentleman client used for the token exchange.
+// rt and reqTimeout, when set, mirror the main client's transport/timeout so
+// that a custom Transport (e.g. for an internal CA or corporate proxy) also
+// applies to authentication requests. reqTimeout defaults to 5s if unset
+func newTokenClient(URL string, rt http.RoundTripper, reqTimeout time.Duration) *gentleman.Client {
+	if reqTimeout <= 0 {
+		reqTimeout = 5 * time.Second
+	}
+
 	cli := gentleman.New()
 	cli.URL(URL)
 	cli.Use(timeout.All(timeout.Timeouts{
-		Request:   5 * time.Second,
+		Request:   reqTimeout,
 		TLS:       5 * time.Second,
 		Dial:      5 * time.Second,
-		KeepAlive: 5 * time.Second,
+		KeepAlive: reqTimeout,
 	}))
+	if rt != nil {
+		cli.Use(transport.Set(rt))
+	}
+	return cli
+}
 
-	provider := NewTokenProvider(cli, Username, Password)
-
+func bearerPlugin(provider TokenProvider) plugin.Plugin {
 	return plugin.NewRequestPlugin(func(ctx *context.Context, h context.Handler) {
 		if token, err := provider.Token(); err == nil {
 			ctx.Request.Header.Set("Authorization", "Bearer "+token)
@@ -73,3 +161,19 @@ func NewTokenPlugin(URL, Username, Password string) plugin.Plugin {
 		}
 	})
 }
+
+// NewTokenPlugin returns a gentleman plugin that authenticates via HTTP Basic
+// token exchange. Use BasicCredentials.NewPlugin instead if the token
+// exchange needs to share a custom transport/timeout with the main client
+func NewTokenPlugin(URL, Username, Password string) plugin.Plugin {
+	provider := NewTokenProvider(newTokenClient(URL, nil, 0), Username, Password)
+	return bearerPlugin(provider)
+}
+
+// NewTOTPTokenPlugin returns a gentleman plugin that authenticates via HTTP
+// Basic plus a TOTP passcode. Use TOTPCredentials.NewPlugin instead if the
+// token exchange needs to share a custom transport/timeout with the main client
+func NewTOTPTokenPlugin(URL, Username, Password, TOTPSecret string) plugin.Plugin {
+	provider := NewTOTPTokenProvider(newTokenClient(URL, nil, 0), Username, Password, TOTPSecret)
+	return bearerPlugin(provider)
+}